@@ -1,9 +1,10 @@
-// TODO(u): Evaluate storing the samples (and residuals) during frame audio
-// decoding in a buffer allocated for the stream. This buffer would be allocated
-// using BlockSize and NChannels from the StreamInfo block, and it could be
-// reused in between calls to Next and ParseNext. This should reduce GC
-// pressure.
-
+// TODO(u): Evaluate plumbing a buffer allocated from BlockSize and
+// NChannels into the frame package itself, so that frame.Parse can reuse
+// it across calls instead of allocating a new set of subframe/residual
+// slices per frame. Stream.buf (see fillBuf) only reuses the copy of the
+// already-decoded samples on the Stream side of that call; it does not
+// yet reduce the GC pressure from decoding itself.
+//
 // TODO: Remove note about encoder API.
 
 // Package flac provides access to FLAC (Free Lossless Audio Codec) streams.
@@ -16,11 +17,14 @@
 // arbitrary order.
 //
 // Please refer to the documentation of the meta [2] and the frame [3] packages
-// for a brief introduction of their respective formats.
+// for a brief introduction of their respective formats. ID3v1 and ID3v2 tags,
+// when present alongside a FLAC stream, are parsed by the id3 [4] package and
+// exposed through Stream.ID3Tags.
 //
 //    [1]: https://www.xiph.org/flac/format.html#stream
 //    [2]: https://godoc.org/github.com/mewkiz/flac/meta
 //    [3]: https://godoc.org/github.com/mewkiz/flac/frame
+//    [4]: https://godoc.org/github.com/mewkiz/flac/id3
 //
 // Note: the Encoder API is experimental until the 1.1.x release. As such, it's
 // API is expected to change.
@@ -33,8 +37,10 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sort"
 
 	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/id3"
 	"github.com/mewkiz/flac/meta"
 )
 
@@ -54,9 +60,37 @@ type Stream struct {
 	// seekTableSize determines how many seek points the seekTable should have if the flac file does not include one
 	// in the metadata.
 	seekTableSize int
+	// seekMode determines the strategy Seek uses to locate frame
+	// boundaries; SeekModeEager by default.
+	seekMode SeekMode
 	// dataStart is the offset of the first frame header since SeekPoint.Offset is relative to this position.
 	dataStart int64
 
+	// buf is a reusable decode buffer used by SamplesInt32 and
+	// SamplesFloat64, one []int32 per channel, each sized from
+	// Info.BlockSize; nil until the first call to Samples, SamplesInt32,
+	// SamplesFloat64, StreamTo or Seek. See fillBuf's comment for what this
+	// buffer does and does not save.
+	buf [][]int32
+	// bufPos is the index of the next unread sample pair in buf.
+	bufPos int
+	// bufLen is the number of valid sample pairs currently held in buf.
+	bufLen int
+	// bufSampleNum is the absolute sample number of buf[0], as reported by
+	// the frame that buf was decoded from.
+	bufSampleNum uint64
+
+	// resyncPolicy controls how Next and ParseNext react to a frame that
+	// fails to parse; ResyncStrict by default.
+	resyncPolicy ResyncPolicy
+	// onResync, if non-nil, is invoked after a successful resync with the
+	// number of bytes that were skipped to find the next valid frame.
+	onResync func(skipped int64)
+
+	// id3Tags holds the ID3v1 and/or ID3v2 tags found alongside the
+	// stream, merged into a single value; nil if neither was present.
+	id3Tags *id3.Tags
+
 	// Underlying io.Reader.
 	r io.Reader
 	// Underlying io.Closer of file if opened with Open and ParseFile, and nil
@@ -71,9 +105,14 @@ type Stream struct {
 // Call Stream.Next to parse the frame header of the next audio frame, and call
 // Stream.ParseNext to parse the entire next frame including audio samples.
 func New(r io.Reader) (stream *Stream, err error) {
+	stream = &Stream{}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		stream.probeID3v1(rs)
+	}
+
 	// Verify FLAC signature and parse the StreamInfo metadata block.
 	br := bufio.NewReader(r)
-	stream = &Stream{r: br}
+	stream.r = br
 	block, err := stream.parseStreamInfo()
 	if err != nil {
 		return nil, err
@@ -103,6 +142,7 @@ func NewSeek(r io.Reader) (stream *Stream, err error) {
 	}
 
 	stream = &Stream{r: rs, seekTableSize: defaultSeekTableSize}
+	stream.probeID3v1(rs)
 
 	// Verify FLAC signature and parse the StreamInfo metadata block.
 	block, err := stream.parseStreamInfo()
@@ -147,6 +187,33 @@ const (
 	defaultSeekTableSize = 100
 )
 
+// SeekMode controls how Stream.Seek locates frame boundaries.
+type SeekMode int
+
+const (
+	// SeekModeEager uses the stream's embedded SeekTable metadata block or,
+	// if absent, builds a seek table on the first call to Seek by scanning
+	// every frame once (see makeSeekTable). This is the default.
+	SeekModeEager SeekMode = iota
+	// SeekModeLazy avoids the up-front scan entirely. Each seek estimates
+	// a byte offset from Info.NSamples and the stream length, then
+	// narrows in on the target with a bounded bisection over frame
+	// headers (see bisectSeek). Discovered (sample, offset) pairs are
+	// cached in the stream's in-memory seek table, so later seeks
+	// converge faster as the cache fills in. SeekModeLazy trades a few
+	// header probes per seek for skipping the full scan SeekModeEager
+	// makes on the first Seek of a stream without a SeekTable block,
+	// which matters on multi-hour archival files.
+	SeekModeLazy
+)
+
+// SetSeekMode sets the strategy Seek uses to locate frame boundaries. It
+// must be called before the first call to Seek, and only has an effect on
+// streams opened through NewSeek.
+func (stream *Stream) SetSeekMode(mode SeekMode) {
+	stream.seekMode = mode
+}
+
 // parseStreamInfo verifies the signature which marks the beginning of a FLAC
 // stream, and parses the StreamInfo metadata block. It returns a boolean value
 // which specifies if the StreamInfo block was the last metadata block of the
@@ -161,7 +228,7 @@ func (stream *Stream) parseStreamInfo() (block *meta.Block, err error) {
 
 	// Skip prepended ID3v2 data.
 	if bytes.Equal(buf[:3], id3Signature) {
-		if err := stream.skipID3v2(); err != nil {
+		if err := stream.skipID3v2(buf[3]); err != nil {
 			return block, err
 		}
 
@@ -188,25 +255,88 @@ func (stream *Stream) parseStreamInfo() (block *meta.Block, err error) {
 	return block, nil
 }
 
-// skipID3v2 skips ID3v2 data prepended to flac files.
-func (stream *Stream) skipID3v2() error {
+// skipID3v2 skips ID3v2 data prepended to flac files, capturing any parsed
+// frames onto stream.id3Tags. verMajor is the ID3v2 major version byte,
+// already consumed by the caller while checking the "ID3" signature.
+func (stream *Stream) skipID3v2(verMajor byte) error {
 	r := bufio.NewReader(stream.r)
 
-	// Discard unnecessary data from the ID3v2 header.
-	if _, err := r.Discard(2); err != nil {
+	// Discard the ID3v2 minor version byte.
+	if _, err := r.Discard(1); err != nil {
 		return err
 	}
 
+	// The flags byte's footer-present bit (0x10), introduced in ID3v2.4,
+	// indicates a 10-byte footer follows the tag body.
+	flags, err := r.ReadByte()
+	if err != nil {
+		return err
+	}
+	hasFooter := flags&0x10 != 0
+
 	// Read the size from the ID3v2 header.
 	var sizeBuf [4]byte
-	if _, err := r.Read(sizeBuf[:]); err != nil {
+	if _, err := io.ReadFull(r, sizeBuf[:]); err != nil {
 		return err
 	}
-	// The size is encoded as a synchsafe integer.
-	size := int(sizeBuf[0])<<21 | int(sizeBuf[1])<<14 | int(sizeBuf[2])<<7 | int(sizeBuf[3])
+	// The size is encoded as a synchsafe integer, and is the size of the
+	// frame data only, excluding the header and, if present, the footer.
+	frameSize := int(sizeBuf[0])<<21 | int(sizeBuf[1])<<14 | int(sizeBuf[2])<<7 | int(sizeBuf[3])
+	totalSize := frameSize
+	if hasFooter {
+		// The footer mirrors the header ("3DI" + version + flags + size);
+		// it is not itself frame data, so it must not be handed to
+		// id3.ParseFrames, which would otherwise misread it as one more
+		// (corrupt) frame.
+		totalSize += 10
+	}
 
-	_, err := r.Discard(size)
-	return err
+	body := make([]byte, totalSize)
+	if _, err := io.ReadFull(r, body); err != nil {
+		return err
+	}
+
+	frames := id3.ParseFrames(int(verMajor), body[:frameSize])
+	stream.mergeID3Tags(&id3.Tags{Frames: frames, V2Raw: body})
+	return nil
+}
+
+// probeID3v1 looks for a trailing 128-byte ID3v1 tag on rs and merges it
+// onto stream.id3Tags. rs must be at the start of the stream; its position
+// is restored before returning. Errors (including the tag simply being
+// absent) are ignored, since ID3v1 detection is best-effort.
+func (stream *Stream) probeID3v1(rs io.ReadSeeker) {
+	tags, err := id3.ParseV1(rs)
+	if err != nil {
+		rs.Seek(0, io.SeekStart)
+		return
+	}
+	stream.mergeID3Tags(tags)
+	rs.Seek(0, io.SeekStart)
+}
+
+// mergeID3Tags merges tags onto stream.id3Tags, with tags' frames taking
+// precedence over any existing frame with the same ID.
+func (stream *Stream) mergeID3Tags(tags *id3.Tags) {
+	if stream.id3Tags == nil {
+		stream.id3Tags = &id3.Tags{Frames: make(map[string]*id3.Frame)}
+	}
+	if tags.V1Raw != nil {
+		stream.id3Tags.V1Raw = tags.V1Raw
+	}
+	if tags.V2Raw != nil {
+		stream.id3Tags.V2Raw = tags.V2Raw
+	}
+	for id, f := range tags.Frames {
+		stream.id3Tags.Frames[id] = f
+	}
+}
+
+// ID3Tags returns the ID3v1 and/or ID3v2 tags found alongside the stream,
+// or nil if neither was present. The VorbisComment metadata block, if
+// any, is exposed separately through Blocks.
+func (stream *Stream) ID3Tags() *id3.Tags {
+	return stream.id3Tags
 }
 
 // Parse creates a new Stream for accessing the metadata blocks and audio
@@ -215,9 +345,14 @@ func (stream *Stream) skipID3v2() error {
 // Call Stream.Next to parse the frame header of the next audio frame, and call
 // Stream.ParseNext to parse the entire next frame including audio samples.
 func Parse(r io.Reader) (stream *Stream, err error) {
+	stream = &Stream{}
+	if rs, ok := r.(io.ReadSeeker); ok {
+		stream.probeID3v1(rs)
+	}
+
 	// Verify FLAC signature and parse the StreamInfo metadata block.
 	br := bufio.NewReader(r)
-	stream = &Stream{r: br}
+	stream.r = br
 	block, err := stream.parseStreamInfo()
 	if err != nil {
 		return nil, err
@@ -301,16 +436,42 @@ func (stream *Stream) Close() error {
 //
 // Call Frame.Parse to parse the audio samples of its subframes.
 func (stream *Stream) Next() (f *frame.Frame, err error) {
-	return frame.New(stream.r)
+	return stream.next(frame.New)
 }
 
 // ParseNext parses the entire next frame including audio samples. It returns
 // io.EOF to signal a graceful end of FLAC stream.
 func (stream *Stream) ParseNext() (f *frame.Frame, err error) {
-	return frame.Parse(stream.r)
+	return stream.next(frame.Parse)
 }
 
-// Seek to a specific sample number in the flac stream.
+// next parses the next frame using parseFn, applying the stream's
+// ResyncPolicy if parseFn fails.
+func (stream *Stream) next(parseFn func(io.Reader) (*frame.Frame, error)) (f *frame.Frame, err error) {
+	f, err = parseFn(stream.r)
+	if err == nil || err == io.EOF || stream.resyncPolicy == ResyncStrict {
+		return f, err
+	}
+
+	// Resynchronization requires Peek/Discard, which is only meaningful
+	// against a buffered reader; Stream.r is a *bufio.Reader whenever the
+	// stream was opened through New or Parse.
+	br, ok := stream.r.(*bufio.Reader)
+	if !ok {
+		return f, err
+	}
+	br = stream.ensureResyncCapacity(br)
+	if rerr := stream.resync(br); rerr != nil {
+		return nil, rerr
+	}
+	return parseFn(stream.r)
+}
+
+// Seek to a specific sample number in the flac stream. It returns the
+// post-seek absolute sample number, i.e. the index of the first sample
+// that will be read from the stream after Seek returns, which is the
+// requested position (sampleNum taken relative to whence, clamped to the
+// valid range of the stream) rather than merely the nearest seek point.
 //
 // sample is valid if:
 // whence == io.SeekEnd and sample is negative
@@ -318,13 +479,10 @@ func (stream *Stream) ParseNext() (f *frame.Frame, err error) {
 // whence == io.SeekCurrent and sample + current sample > 0 and < stream.Info.NSamples
 //
 // If sample does not match one of the above conditions then the result will
-// probably be seeking to the beginning or very end of the data and no error
-// will be returned.
-//
-// The returned value, result, represents the closest match to sampleNum from the seek table.
-// Note that result will always be >= sampleNum
+// be seeking to the beginning or very end of the data, clamped to the
+// stream's valid range, and no error will be returned.
 func (stream *Stream) Seek(sampleNum int64, whence int) (result int64, err error) {
-	if stream.seekTable == nil && stream.seekTableSize > 0 {
+	if stream.seekTable == nil && stream.seekMode == SeekModeEager && stream.seekTableSize > 0 {
 		if err := stream.makeSeekTable(); err != nil {
 			return 0, err
 		}
@@ -332,45 +490,207 @@ func (stream *Stream) Seek(sampleNum int64, whence int) (result int64, err error
 
 	rs := stream.r.(io.ReadSeeker)
 
-	var point meta.SeekPoint
+	var target int64
 	switch whence {
 	case io.SeekStart:
-		point = stream.searchFromStart(sampleNum)
+		target = sampleNum
 	case io.SeekCurrent:
-		point, err = stream.searchFromCurrent(sampleNum, rs)
+		cur, err := stream.Tell()
+		if err != nil {
+			return 0, err
+		}
+		target = cur + sampleNum
 	case io.SeekEnd:
-		point = stream.searchFromEnd(sampleNum)
+		target = int64(stream.Info.NSamples) + sampleNum
 	default:
 		return 0, ErrInvalidSeek
 	}
+	if target < 0 {
+		target = 0
+	}
+	if target > int64(stream.Info.NSamples) {
+		target = int64(stream.Info.NSamples)
+	}
 
-	if err != nil {
+	var point meta.SeekPoint
+	if stream.seekTable != nil && stream.seekMode != SeekModeLazy {
+		point = stream.searchFromStart(target)
+	} else {
+		point, err = stream.bisectSeek(rs, uint64(target))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if _, err := rs.Seek(stream.dataStart+int64(point.Offset), io.SeekStart); err != nil {
 		return 0, err
 	}
+	stream.bufPos, stream.bufLen = 0, 0
 
-	_, err = rs.Seek(stream.dataStart+int64(point.Offset), io.SeekStart)
-	return int64(point.SampleNum), err
+	// Decode forward from the seek point, frame by frame, until the target
+	// sample falls within the buffered frame. This corrects for the fact
+	// that seek points (whether from the seek table or the bisection
+	// fallback) only guarantee a frame boundary at or before target.
+	for {
+		if err := stream.fillBuf(); err != nil {
+			// target is clamped to Info.NSamples above, so landing exactly
+			// on it drives this loop into the real end of the stream:
+			// fillBuf has nothing left to decode and reports io.EOF even
+			// though the seek itself is valid. Treat that as arriving at
+			// the last already-buffered sample rather than failing Seek.
+			if err == io.EOF && stream.bufLen > 0 && target >= int64(stream.Info.NSamples) {
+				stream.bufPos = stream.bufLen
+				break
+			}
+			return 0, err
+		}
+		if int64(stream.bufSampleNum)+int64(stream.bufLen) > target {
+			break
+		}
+	}
+	if skip := target - int64(stream.bufSampleNum); skip > 0 {
+		stream.bufPos = int(skip)
+	}
+
+	return stream.Tell()
+}
+
+// Tell returns the absolute sample number of the next sample that will be
+// read from the stream by Samples, SamplesInt32, SamplesFloat64 or
+// StreamTo.
+func (stream *Stream) Tell() (int64, error) {
+	return int64(stream.bufSampleNum) + int64(stream.bufPos), nil
 }
 
-func (stream *Stream) searchFromCurrent(sample int64, rs io.ReadSeeker) (p meta.SeekPoint, err error) {
-	o, err := rs.Seek(0, io.SeekCurrent)
+// bisectSeek locates the nearest frame at or before target by binary
+// searching over frame headers between dataStart and the end of the
+// stream. It is used both as a fallback when no seek table is available
+// (because the stream lacks a SeekTable metadata block and seekTableSize is
+// 0) and as the primary lookup strategy under SeekModeLazy.
+//
+// Any previously cached seek points (from an earlier bisectSeek call under
+// SeekModeLazy) are used to narrow the search range before bisecting, and
+// the first probe is placed at a byte offset estimated from Info.NSamples
+// rather than the midpoint of the whole stream, so that well-behaved
+// (roughly constant bitrate) files converge in very few probes.
+func (stream *Stream) bisectSeek(rs io.ReadSeeker, target uint64) (meta.SeekPoint, error) {
+	end, err := rs.Seek(0, io.SeekEnd)
 	if err != nil {
-		return p, err
+		return meta.SeekPoint{}, err
+	}
+
+	lo, hi := stream.dataStart, end
+	guess := int64(-1)
+	if stream.Info.NSamples > 0 {
+		frac := float64(target) / float64(stream.Info.NSamples)
+		guess = stream.dataStart + int64(frac*float64(end-stream.dataStart))
+		if guess < lo {
+			guess = lo
+		} else if guess >= hi {
+			guess = hi - 1
+		}
+	}
+
+	// Narrow the search range using any previously cached seek points.
+	if stream.seekTable != nil {
+		for _, p := range stream.seekTable.Points {
+			off := stream.dataStart + int64(p.Offset)
+			if p.SampleNum <= target && off > lo {
+				lo = off
+			}
+			if p.SampleNum > target && off < hi {
+				hi = off
+			}
+		}
 	}
 
-	offset := o - stream.dataStart
-	for _, p = range stream.seekTable.Points {
-		if int64(p.Offset) >= offset {
-			return stream.searchFromStart(int64(p.SampleNum) + sample), nil
+	best := meta.SeekPoint{}
+	probed := false
+	for lo < hi {
+		mid := lo + (hi-lo)/2
+		if !probed && guess >= lo && guess < hi {
+			mid = guess
+		}
+		probed = true
+
+		off, f, err := stream.probeFrame(rs, mid, end)
+		if err != nil {
+			hi = mid
+			continue
 		}
+		sample := f.SampleNumber()
+		if sample <= target {
+			best = meta.SeekPoint{SampleNum: sample, Offset: uint64(off - stream.dataStart), NSamples: f.BlockSize}
+			lo = off + 1
+		} else {
+			hi = mid
+		}
+	}
+
+	if stream.seekMode == SeekModeLazy && best.NSamples > 0 {
+		stream.cacheSeekPoint(best)
 	}
-	return p, nil
+	return best, nil
 }
 
-// searchFromEnd expects sample to be negative.
-// If it is positive, it's ok, the last seek point will be returned.
-func (stream *Stream) searchFromEnd(sample int64) (p meta.SeekPoint) {
-	return stream.searchFromStart(int64(stream.Info.NSamples) + sample)
+// cacheSeekPoint inserts p into stream.seekTable under SeekModeLazy,
+// creating the table if necessary and keeping points ordered by
+// SampleNum, as the points of a SeekTable metadata block are.
+func (stream *Stream) cacheSeekPoint(p meta.SeekPoint) {
+	if stream.seekTable == nil {
+		stream.seekTable = &meta.SeekTable{}
+	}
+	pts := stream.seekTable.Points
+	i := sort.Search(len(pts), func(i int) bool { return pts[i].SampleNum >= p.SampleNum })
+	if i < len(pts) && pts[i].SampleNum == p.SampleNum {
+		pts[i] = p
+		return
+	}
+	pts = append(pts, meta.SeekPoint{})
+	copy(pts[i+1:], pts[i:])
+	pts[i] = p
+	stream.seekTable.Points = pts
+}
+
+// probeFrame scans forward from pos for the next valid frame sync code and
+// parses its header, returning the frame's absolute byte offset and
+// header.
+func (stream *Stream) probeFrame(rs io.ReadSeeker, pos, end int64) (int64, *frame.Frame, error) {
+	off, err := findSyncCode(rs, pos, end)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := rs.Seek(off, io.SeekStart); err != nil {
+		return 0, nil, err
+	}
+	f, err := frame.New(rs)
+	if err != nil {
+		return 0, nil, err
+	}
+	return off, f, nil
+}
+
+// findSyncCode scans [from, to) for the 14-bit FLAC frame sync code
+// (0x3FFE) and returns the byte offset of its first byte.
+func findSyncCode(rs io.ReadSeeker, from, to int64) (int64, error) {
+	if _, err := rs.Seek(from, io.SeekStart); err != nil {
+		return 0, err
+	}
+	br := bufio.NewReader(rs)
+	pos := from
+	var prev byte
+	for pos < to {
+		b, err := br.ReadByte()
+		if err != nil {
+			return 0, err
+		}
+		if prev == 0xFF && b&0xFC == 0xF8 {
+			return pos - 1, nil
+		}
+		prev = b
+		pos++
+	}
+	return 0, io.EOF
 }
 
 func (stream *Stream) searchFromStart(sample int64) (p meta.SeekPoint) {
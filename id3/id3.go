@@ -0,0 +1,159 @@
+// Package id3 provides minimal parsing of ID3v1 and ID3v2 tags found
+// prepended or appended to audio streams.
+//
+// ID3 is not part of the FLAC specification; tags are typically added by
+// tools that also handle other audio formats. This package exposes just
+// enough structure for callers that want to read the common text frames
+// or round-trip the raw tag bytes verbatim on re-encode, without pulling
+// in a full ID3 implementation.
+package id3
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNoTag is returned by ParseV1 when the input does not contain an
+// ID3v1 tag.
+var ErrNoTag = errors.New("id3: no tag found")
+
+// A Frame is a single parsed ID3v2 frame.
+type Frame struct {
+	// ID is the 4-character frame identifier, e.g. "TIT2".
+	ID string
+	// Data is the frame's raw, undecoded body.
+	Data []byte
+}
+
+// Tags holds the ID3 frames parsed from a stream, alongside the raw bytes
+// of each tag so that callers can round-trip them verbatim on re-encode.
+type Tags struct {
+	// Frames holds every parsed frame, keyed by its 4-character frame ID.
+	// Frames synthesized from an ID3v1 tag use the equivalent ID3v2 frame
+	// ID (e.g. the ID3v1 title field is stored under "TIT2").
+	Frames map[string]*Frame
+
+	// V2Raw is the raw bytes of the ID3v2 tag that follow its 10-byte
+	// header (extended header if present, frames, padding and, if present,
+	// the 10-byte footer), or nil if no ID3v2 tag was found.
+	V2Raw []byte
+	// V1Raw is the raw 128 bytes of the ID3v1 tag, or nil if no ID3v1 tag
+	// was found.
+	V1Raw []byte
+}
+
+// Title returns the value of the TIT2 (title) frame, or "" if absent.
+func (t *Tags) Title() string { return t.text("TIT2") }
+
+// Artist returns the value of the TPE1 (lead performer) frame, or "" if
+// absent.
+func (t *Tags) Artist() string { return t.text("TPE1") }
+
+// Album returns the value of the TALB (album) frame, or "" if absent.
+func (t *Tags) Album() string { return t.text("TALB") }
+
+// Track returns the value of the TRCK (track number) frame, or "" if
+// absent.
+func (t *Tags) Track() string { return t.text("TRCK") }
+
+// Picture returns the raw body of the APIC (attached picture) frame, or
+// nil if absent. APIC's internal layout (MIME type, picture type,
+// description, image data) is out of scope for this package; callers
+// wanting those fields should parse Data themselves.
+func (t *Tags) Picture() []byte {
+	if f, ok := t.Frames["APIC"]; ok {
+		return f.Data
+	}
+	return nil
+}
+
+// text returns the decoded value of a text-information frame (the T*
+// frames), or "" if the frame is absent.
+func (t *Tags) text(id string) string {
+	f, ok := t.Frames[id]
+	if !ok || len(f.Data) == 0 {
+		return ""
+	}
+	// The first byte of a text frame's body is its text encoding; encoding
+	// 0x00 (ISO-8859-1) and 0x03 (UTF-8) are both represented well enough
+	// here by a direct byte-to-string conversion.
+	return strings.TrimRight(string(f.Data[1:]), "\x00")
+}
+
+// ParseFrames parses the sequence of ID3v2 frames in body, the tag payload
+// following the 10-byte header (and any extended header, already
+// stripped). version is the ID3v2 major version (e.g. 3 for ID3v2.3.0);
+// frame sizes are synchsafe as of ID3v2.4 and plain big-endian before it.
+func ParseFrames(version int, body []byte) map[string]*Frame {
+	frames := make(map[string]*Frame)
+	for len(body) >= 10 {
+		id := string(body[:4])
+		if id == "\x00\x00\x00\x00" {
+			break // padding
+		}
+
+		var size int
+		if version >= 4 {
+			size = int(body[4])<<21 | int(body[5])<<14 | int(body[6])<<7 | int(body[7])
+		} else {
+			size = int(binary.BigEndian.Uint32(body[4:8]))
+		}
+		body = body[10:]
+		if size < 0 || size > len(body) {
+			break
+		}
+
+		frames[id] = &Frame{ID: id, Data: body[:size]}
+		body = body[size:]
+	}
+	return frames
+}
+
+// id3v1FieldMap maps ID3v1's fixed fields onto their ID3v2 text-frame
+// equivalents.
+var id3v1FieldMap = []struct {
+	id         string
+	start, end int
+}{
+	{"TIT2", 3, 33},
+	{"TPE1", 33, 63},
+	{"TALB", 63, 93},
+}
+
+// ParseV1 parses the trailing 128-byte ID3v1 tag from rs, if present. It
+// returns ErrNoTag if the last 128 bytes of rs do not start with the "TAG"
+// signature. The reader's position is left at the start of the ID3v1 tag
+// on success, and is unspecified on error.
+func ParseV1(rs io.ReadSeeker) (*Tags, error) {
+	size, err := rs.Seek(0, io.SeekEnd)
+	if err != nil {
+		return nil, err
+	}
+	if size < 128 {
+		return nil, ErrNoTag
+	}
+	if _, err := rs.Seek(-128, io.SeekEnd); err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, 128)
+	if _, err := io.ReadFull(rs, buf); err != nil {
+		return nil, err
+	}
+	if string(buf[:3]) != "TAG" {
+		return nil, ErrNoTag
+	}
+	if _, err := rs.Seek(-128, io.SeekEnd); err != nil {
+		return nil, err
+	}
+
+	t := &Tags{Frames: make(map[string]*Frame), V1Raw: buf}
+	for _, f := range id3v1FieldMap {
+		data := bytes.TrimRight(buf[f.start:f.end], "\x00")
+		t.Frames[f.id] = &Frame{ID: f.id, Data: append([]byte{0x00}, data...)}
+	}
+	return t, nil
+}
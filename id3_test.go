@@ -0,0 +1,65 @@
+package flac
+
+import (
+	"bytes"
+	"testing"
+)
+
+// synchsafe encodes n as a 4-byte ID3v2 synchsafe integer.
+func synchsafe(n int) [4]byte {
+	return [4]byte{
+		byte(n >> 21 & 0x7F),
+		byte(n >> 14 & 0x7F),
+		byte(n >> 7 & 0x7F),
+		byte(n & 0x7F),
+	}
+}
+
+// TestSkipID3v2Footer checks that, when the ID3v2.4 footer-present flag
+// is set, the 10 raw footer bytes are excluded from id3.ParseFrames (they
+// aren't a frame and would otherwise be misinterpreted as one, corrupting
+// Tags.Frames) while still round-tripping into V2Raw.
+func TestSkipID3v2Footer(t *testing.T) {
+	// A single TIT2 frame: ID(4) + size(4, big-endian for v2.3) + flags(2)
+	// + data(3: encoding byte + "Hi").
+	frame := []byte{}
+	frame = append(frame, []byte("TIT2")...)
+	frame = append(frame, 0x00, 0x00, 0x00, 0x03) // size = 3
+	frame = append(frame, 0x00, 0x00)             // flags
+	frame = append(frame, 0x00, 'H', 'i')         // encoding + text
+
+	// A footer that mirrors the header; its bytes must never be
+	// interpreted as frame data.
+	footer := []byte{}
+	footer = append(footer, []byte("3DI")...)
+	footer = append(footer, 0x03, 0x00) // version
+	footer = append(footer, 0x10)       // flags
+	fsz := synchsafe(len(frame))
+	footer = append(footer, fsz[:]...)
+
+	var buf bytes.Buffer
+	buf.WriteByte(0x00) // minor version
+	buf.WriteByte(0x10) // flags: footer present
+	sz := synchsafe(len(frame))
+	buf.Write(sz[:])
+	buf.Write(frame)
+	buf.Write(footer)
+
+	stream := &Stream{r: bytes.NewReader(buf.Bytes())}
+	if err := stream.skipID3v2(0x03); err != nil {
+		t.Fatalf("skipID3v2: unexpected error: %v", err)
+	}
+
+	if stream.id3Tags == nil {
+		t.Fatal("skipID3v2: stream.id3Tags is nil")
+	}
+	if got, want := len(stream.id3Tags.Frames), 1; got != want {
+		t.Fatalf("skipID3v2: parsed %d frames, want %d (footer bytes leaked into ParseFrames)", got, want)
+	}
+	if got, want := stream.id3Tags.Title(), "Hi"; got != want {
+		t.Errorf("skipID3v2: Title() = %q, want %q", got, want)
+	}
+	if got, want := len(stream.id3Tags.V2Raw), len(frame)+len(footer); got != want {
+		t.Errorf("skipID3v2: len(V2Raw) = %d, want %d (frame + footer, for round-trip)", got, want)
+	}
+}
@@ -0,0 +1,160 @@
+package flac
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+
+	"github.com/mewkiz/flac/frame"
+)
+
+// A ResyncPolicy controls how Stream.Next and Stream.ParseNext react to a
+// frame that fails to parse, whether due to a malformed header or a CRC
+// mismatch.
+type ResyncPolicy int
+
+const (
+	// ResyncStrict reports the decode error as-is. This is the default
+	// policy.
+	ResyncStrict ResyncPolicy = iota
+	// ResyncSkipBadFrame scans forward for the next byte offset at which a
+	// frame header parses successfully (including its header CRC-8), and
+	// resumes decoding there.
+	ResyncSkipBadFrame
+	// ResyncBestEffort behaves like ResyncSkipBadFrame, but additionally
+	// requires the candidate frame's footer CRC-16 to validate before
+	// committing to it, so that arbitrary garbage between frames (such as
+	// an embedded ID3v2 tag) is not mistaken for a valid sync code.
+	ResyncBestEffort
+)
+
+// SetResyncPolicy sets the policy Next and ParseNext use to recover from a
+// frame that fails to parse. Resynchronization is only available on
+// streams opened through New or Parse; it has no effect on streams opened
+// through NewSeek, since the unbuffered io.ReadSeeker they wrap cannot be
+// peeked without disturbing the seek position.
+func (stream *Stream) SetResyncPolicy(policy ResyncPolicy) {
+	stream.resyncPolicy = policy
+}
+
+// OnResync registers a callback invoked after a successful resync with the
+// number of bytes that were skipped over to reach the next valid frame,
+// allowing callers to log the damaged region of the stream.
+func (stream *Stream) OnResync(fn func(skipped int64)) {
+	stream.onResync = fn
+}
+
+// syncCodeLookahead bounds how far resync peeks ahead to validate a
+// candidate frame sync code, generously sized to cover the largest
+// possible frame header plus its CRC-8.
+const syncCodeLookahead = 32
+
+// minResyncBufferSize is the smallest buffer size ensureResyncCapacity
+// will request, matching bufio's own default.
+const minResyncBufferSize = 4096
+
+// worstCaseFrameSize returns a generous, heuristic upper bound on the
+// encoded size of a single frame, used to size the buffered reader so
+// that validateCandidate's full-frame peek under ResyncBestEffort is
+// never truncated by (*bufio.Reader).Peek.
+func (stream *Stream) worstCaseFrameSize() int {
+	n := int(stream.Info.BlockSize)*int(stream.Info.NChannels)*int(stream.Info.BitsPerSample)/4 + 64
+	if n < minResyncBufferSize {
+		n = minResyncBufferSize
+	}
+	return n
+}
+
+// ensureResyncCapacity grows stream.r's buffer, if necessary, so that a
+// full-frame Peek during ResyncBestEffort validation cannot be truncated
+// by (*bufio.Reader).Peek returning io.ErrBufferFull. It wraps the
+// existing *bufio.Reader in a larger one rather than discarding it, so no
+// already-buffered bytes are lost, and returns the (possibly new) reader
+// for the caller to use.
+func (stream *Stream) ensureResyncCapacity(br *bufio.Reader) *bufio.Reader {
+	if stream.resyncPolicy != ResyncBestEffort {
+		return br
+	}
+	need := stream.worstCaseFrameSize()
+	if br.Size() >= need {
+		return br
+	}
+	grown := bufio.NewReaderSize(br, need)
+	stream.r = grown
+	return grown
+}
+
+// resync scans br for the next byte offset at which a frame header parses
+// successfully, discards everything before it, and reports the number of
+// skipped bytes through onResync.
+func (stream *Stream) resync(br *bufio.Reader) error {
+	var skipped int64
+	for {
+		peek, err := br.Peek(syncCodeLookahead)
+		if len(peek) < 2 {
+			if err != nil {
+				return err
+			}
+			return io.ErrUnexpectedEOF
+		}
+
+		found := false
+		for i := 0; i+1 < len(peek); i++ {
+			if peek[i] != 0xFF || peek[i+1]&0xFC != 0xF8 {
+				continue
+			}
+			if _, derr := br.Discard(i); derr != nil {
+				return derr
+			}
+			skipped += int64(i)
+
+			if stream.validateCandidate(br) {
+				if stream.onResync != nil {
+					stream.onResync(skipped)
+				}
+				return nil
+			}
+
+			// False positive; discard the sync byte itself and keep
+			// scanning from the next one.
+			if _, derr := br.Discard(1); derr != nil {
+				return derr
+			}
+			skipped++
+			found = true
+			break
+		}
+		if found {
+			continue
+		}
+
+		// No candidate in this window; keep the last byte, since it may be
+		// the first half of a sync code split across windows.
+		adv := len(peek) - 1
+		if _, derr := br.Discard(adv); derr != nil {
+			return derr
+		}
+		skipped += int64(adv)
+	}
+}
+
+// validateCandidate reports whether br is positioned at a byte offset that
+// parses as a valid frame header, and, under ResyncBestEffort, whether the
+// frame's footer CRC-16 also validates.
+func (stream *Stream) validateCandidate(br *bufio.Reader) bool {
+	peek, _ := br.Peek(syncCodeLookahead)
+	if _, err := frame.New(bytes.NewReader(peek)); err != nil {
+		return false
+	}
+	if stream.resyncPolicy != ResyncBestEffort {
+		return true
+	}
+
+	// Confirm the footer CRC-16 also validates before committing, so that
+	// best-effort resync does not lock onto a false-positive sync code
+	// inside garbage data. The lookahead is a generous, heuristic bound on
+	// a single frame's encoded size.
+	full, _ := br.Peek(stream.worstCaseFrameSize())
+	_, err := frame.Parse(bytes.NewReader(full))
+	return err == nil
+}
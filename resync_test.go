@@ -0,0 +1,52 @@
+package flac
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/mewkiz/flac/meta"
+)
+
+// TestEnsureResyncCapacityGrowsBuffer checks that validateCandidate's
+// full-frame Peek under ResyncBestEffort gets a buffer large enough not to
+// be silently truncated by bufio's default 4096-byte capacity, which would
+// otherwise reject every genuine frame as a false positive for any
+// real-world block size.
+func TestEnsureResyncCapacityGrowsBuffer(t *testing.T) {
+	stream := &Stream{
+		Info:         &meta.StreamInfo{BlockSize: 4096, NChannels: 2, BitsPerSample: 16},
+		resyncPolicy: ResyncBestEffort,
+	}
+
+	want := stream.worstCaseFrameSize()
+	if want <= minResyncBufferSize {
+		t.Fatalf("worstCaseFrameSize() = %d, want > default bufio size %d for this test to be meaningful", want, minResyncBufferSize)
+	}
+
+	br := bufio.NewReader(strings.NewReader(""))
+	grown := stream.ensureResyncCapacity(br)
+	if grown.Size() < want {
+		t.Fatalf("ensureResyncCapacity: buffer size = %d, want >= %d", grown.Size(), want)
+	}
+
+	// A second call with an already-sufficient buffer should be a no-op.
+	again := stream.ensureResyncCapacity(grown)
+	if again != grown {
+		t.Fatalf("ensureResyncCapacity: expected no-op on an already-sufficient buffer")
+	}
+}
+
+// TestEnsureResyncCapacitySkipStrict confirms growth only happens under
+// ResyncBestEffort, since ResyncSkipBadFrame only ever needs the small
+// header-only lookahead.
+func TestEnsureResyncCapacitySkipBadFrame(t *testing.T) {
+	stream := &Stream{
+		Info:         &meta.StreamInfo{BlockSize: 4096, NChannels: 2, BitsPerSample: 16},
+		resyncPolicy: ResyncSkipBadFrame,
+	}
+	br := bufio.NewReader(strings.NewReader(""))
+	if got := stream.ensureResyncCapacity(br); got != br {
+		t.Fatalf("ensureResyncCapacity: expected no-op under ResyncSkipBadFrame")
+	}
+}
@@ -0,0 +1,135 @@
+package flac
+
+import "io"
+
+// A SampleWriter receives decoded audio samples from Stream.StreamTo,
+// following the convention established by github.com/faiface/beep.Streamer.
+type SampleWriter interface {
+	WriteSamples(samples [][2]float64) (n int, err error)
+}
+
+// fillBuf decodes the next frame and copies its samples into the stream's
+// reusable per-channel buffer, one []int32 per channel (so files with more
+// than two channels are buffered in full, not just the stereo pair exposed
+// below), each sized from Info.BlockSize and grown on demand.
+//
+// NOTE: this only avoids re-copying the decoded samples between calls to
+// Samples/SamplesInt32/SamplesFloat64/StreamTo; frame.Parse (called via
+// Stream.ParseNext above) still allocates a fresh set of subframe and
+// residual slices for every frame, since plumbing a reusable buffer into
+// the frame package itself is out of scope here. See the TODO at the top
+// of flac.go.
+func (stream *Stream) fillBuf() error {
+	f, err := stream.ParseNext()
+	if err != nil {
+		return err
+	}
+
+	n := int(f.BlockSize)
+	size := n
+	if bs := int(stream.Info.BlockSize); bs > size {
+		size = bs
+	}
+	nchan := len(f.Subframes)
+	if cap(stream.buf) < nchan {
+		stream.buf = make([][]int32, nchan)
+	}
+	stream.buf = stream.buf[:nchan]
+	for ch := 0; ch < nchan; ch++ {
+		if cap(stream.buf[ch]) < n {
+			stream.buf[ch] = make([]int32, size)
+		}
+		stream.buf[ch] = stream.buf[ch][:n]
+		copy(stream.buf[ch], f.Subframes[ch].Samples)
+	}
+
+	stream.bufPos = 0
+	stream.bufLen = n
+	stream.bufSampleNum = f.SampleNumber()
+	return nil
+}
+
+// stereoSample returns the left/right pair for sample index i of the
+// stream's buffered frame, duplicating a mono channel to both outputs and
+// otherwise taking channels 0 and 1 (channels 2+, if any, are buffered by
+// fillBuf but are not exposed by the stereo-pair API below).
+func (stream *Stream) stereoSample(i int) (l, r int32) {
+	l = stream.buf[0][i]
+	r = l
+	if len(stream.buf) > 1 {
+		r = stream.buf[1][i]
+	}
+	return l, r
+}
+
+// SamplesInt32 decodes consecutive audio frames into dst, advancing across
+// frame boundaries transparently, and returns the number of sample pairs
+// written. Mono streams are expanded to both channels of dst, and streams
+// with more than two channels contribute only their first two.
+// SamplesInt32 returns io.EOF once the underlying stream is exhausted.
+func (stream *Stream) SamplesInt32(dst [][2]int32) (n int, err error) {
+	for n < len(dst) {
+		if stream.bufPos >= stream.bufLen {
+			if err := stream.fillBuf(); err != nil {
+				return n, err
+			}
+		}
+		for n < len(dst) && stream.bufPos < stream.bufLen {
+			l, r := stream.stereoSample(stream.bufPos)
+			dst[n] = [2]int32{l, r}
+			stream.bufPos++
+			n++
+		}
+	}
+	return n, nil
+}
+
+// SamplesFloat64 decodes consecutive audio frames into dst, normalizing
+// each sample to the range [-1, 1] based on Info.BitsPerSample. It follows
+// the same frame-advancing and channel-expansion rules as SamplesInt32.
+func (stream *Stream) SamplesFloat64(dst [][2]float64) (n int, err error) {
+	scale := 1 / float64(int64(1)<<(stream.Info.BitsPerSample-1))
+	for n < len(dst) {
+		if stream.bufPos >= stream.bufLen {
+			if err := stream.fillBuf(); err != nil {
+				return n, err
+			}
+		}
+		for n < len(dst) && stream.bufPos < stream.bufLen {
+			l, r := stream.stereoSample(stream.bufPos)
+			dst[n] = [2]float64{float64(l) * scale, float64(r) * scale}
+			stream.bufPos++
+			n++
+		}
+	}
+	return n, nil
+}
+
+// Samples decodes consecutive audio frames into dst. It is an alias for
+// SamplesFloat64, matching the Streamer convention used by
+// github.com/faiface/beep so that a *Stream can be adapted into a beep
+// pipeline with a thin wrapper.
+func (stream *Stream) Samples(dst [][2]float64) (n int, err error) {
+	return stream.SamplesFloat64(dst)
+}
+
+// StreamTo decodes the remainder of the stream and writes the resulting
+// samples to w in fixed-size windows, reusing a single buffer across
+// writes.
+func (stream *Stream) StreamTo(w SampleWriter) error {
+	buf := make([][2]float64, 4096)
+	for {
+		n, err := stream.SamplesFloat64(buf)
+		if n > 0 {
+			if _, werr := w.WriteSamples(buf[:n]); werr != nil {
+				return werr
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
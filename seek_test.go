@@ -0,0 +1,81 @@
+package flac
+
+import (
+	"testing"
+
+	"github.com/mewkiz/flac/meta"
+)
+
+// TestCacheSeekPointKeepsTableSorted checks that cacheSeekPoint keeps
+// stream.seekTable.Points sorted by SampleNum (the order of a SeekTable
+// metadata block's points) whether the new point is inserted, appended, or
+// replaces an existing entry at the same SampleNum.
+func TestCacheSeekPointKeepsTableSorted(t *testing.T) {
+	stream := &Stream{}
+
+	stream.cacheSeekPoint(meta.SeekPoint{SampleNum: 100, Offset: 1000})
+	stream.cacheSeekPoint(meta.SeekPoint{SampleNum: 300, Offset: 3000})
+	stream.cacheSeekPoint(meta.SeekPoint{SampleNum: 200, Offset: 2000})
+
+	got := stream.seekTable.Points
+	want := []uint64{100, 200, 300}
+	if len(got) != len(want) {
+		t.Fatalf("len(Points) = %d, want %d (%v)", len(got), len(want), got)
+	}
+	for i, w := range want {
+		if got[i].SampleNum != w {
+			t.Fatalf("Points[%d].SampleNum = %d, want %d (table not sorted: %v)", i, got[i].SampleNum, w, got)
+		}
+	}
+
+	// Caching a point at an existing SampleNum replaces it in place rather
+	// than growing the table.
+	stream.cacheSeekPoint(meta.SeekPoint{SampleNum: 200, Offset: 9999})
+	if n := len(stream.seekTable.Points); n != 3 {
+		t.Fatalf("len(Points) = %d after replace, want 3", n)
+	}
+	if off := stream.seekTable.Points[1].Offset; off != 9999 {
+		t.Fatalf("Points[1].Offset = %d, want 9999 (replace did not update in place)", off)
+	}
+}
+
+// TestSearchFromStart checks that searchFromStart returns a seek point
+// whose SampleNum is never past the requested sample, which is the
+// precondition Seek relies on to decode forward from a frame boundary
+// without overshooting it. Note that for an exact match at a non-zero
+// index it returns the preceding point rather than the match itself (only
+// the first table entry is ever returned in full); that's one frame
+// short of optimal, not incorrect, since Seek's forward-fill loop still
+// lands on the right sample from there.
+func TestSearchFromStart(t *testing.T) {
+	stream := &Stream{
+		seekTable: &meta.SeekTable{
+			Points: []meta.SeekPoint{
+				{SampleNum: 0, Offset: 0},
+				{SampleNum: 100, Offset: 1000},
+				{SampleNum: 200, Offset: 2000},
+			},
+		},
+	}
+
+	cases := []struct {
+		sample     int64
+		wantOffset uint64
+	}{
+		{sample: 0, wantOffset: 0},
+		{sample: 50, wantOffset: 0},
+		{sample: 100, wantOffset: 0},
+		{sample: 150, wantOffset: 1000},
+		{sample: 200, wantOffset: 1000},
+		{sample: 9999, wantOffset: 2000},
+	}
+	for _, c := range cases {
+		got := stream.searchFromStart(c.sample)
+		if got.Offset != c.wantOffset {
+			t.Errorf("searchFromStart(%d).Offset = %d, want %d", c.sample, got.Offset, c.wantOffset)
+		}
+		if int64(got.SampleNum) > c.sample && c.sample != 0 {
+			t.Errorf("searchFromStart(%d).SampleNum = %d, overshoots requested sample", c.sample, got.SampleNum)
+		}
+	}
+}
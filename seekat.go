@@ -0,0 +1,213 @@
+package flac
+
+import (
+	"container/list"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// readerAtSeeker adapts an io.ReaderAt of known size into an io.ReadSeeker
+// for use with NewSeek.
+type readerAtSeeker struct {
+	r    io.ReaderAt
+	size int64
+	pos  int64
+}
+
+func (s *readerAtSeeker) Read(p []byte) (n int, err error) {
+	if s.pos >= s.size {
+		return 0, io.EOF
+	}
+	n, err = s.r.ReadAt(p, s.pos)
+	s.pos += int64(n)
+	return n, err
+}
+
+func (s *readerAtSeeker) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = s.pos + offset
+	case io.SeekEnd:
+		abs = s.size + offset
+	default:
+		return 0, errors.New("flac.readerAtSeeker.Seek: invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("flac.readerAtSeeker.Seek: negative position")
+	}
+	s.pos = abs
+	return abs, nil
+}
+
+// NewSeekAt returns a seek-enabled Stream over r, a random-access source of
+// known size such as an os.File or an in-memory buffer. Unlike NewSeek, r
+// need not implement io.Seeker.
+//
+// The returned stream defaults to SeekModeLazy rather than NewSeek's
+// SeekModeEager, since r (and especially the httpRangeReader wrapped by
+// NewSeekHTTP) may be expensive to scan in full up front; call
+// SetSeekMode(SeekModeEager) to opt back into a one-time prescan.
+func NewSeekAt(r io.ReaderAt, size int64) (stream *Stream, err error) {
+	stream, err = NewSeek(&readerAtSeeker{r: r, size: size})
+	if stream != nil {
+		stream.SetSeekMode(SeekModeLazy)
+	}
+	return stream, err
+}
+
+// httpRangeChunkSize is the granularity at which httpRangeReader issues and
+// caches Range requests.
+const httpRangeChunkSize = 64 * 1024
+
+// httpRangeCacheChunks is the number of chunks an httpRangeReader keeps
+// cached before evicting the least recently used one.
+const httpRangeCacheChunks = 32
+
+// httpRangeReader adapts an HTTP resource that supports Range requests
+// into an io.ReaderAt, caching recently accessed chunks in an LRU so that
+// re-reading the same region of a remote file does not re-fetch it.
+type httpRangeReader struct {
+	url    string
+	client *http.Client
+	size   int64
+
+	mu     sync.Mutex
+	lru    *list.List
+	chunks map[int64]*list.Element
+}
+
+// rangeChunk is the payload of an httpRangeReader LRU entry.
+type rangeChunk struct {
+	index int64
+	data  []byte
+}
+
+func newHTTPRangeReader(url string, client *http.Client, size int64) *httpRangeReader {
+	return &httpRangeReader{
+		url:    url,
+		client: client,
+		size:   size,
+		lru:    list.New(),
+		chunks: make(map[int64]*list.Element),
+	}
+}
+
+// ReadAt implements io.ReaderAt, assembling p from one or more cached or
+// freshly-fetched chunks.
+func (h *httpRangeReader) ReadAt(p []byte, off int64) (n int, err error) {
+	for n < len(p) {
+		if off+int64(n) >= h.size {
+			return n, io.EOF
+		}
+		idx := (off + int64(n)) / httpRangeChunkSize
+		data, err := h.chunk(idx)
+		if err != nil {
+			return n, err
+		}
+		chunkOff := (off + int64(n)) % httpRangeChunkSize
+		if chunkOff >= int64(len(data)) {
+			return n, io.EOF
+		}
+		n += copy(p[n:], data[chunkOff:])
+	}
+	return n, nil
+}
+
+// chunk returns the bytes of chunk idx, fetching it over HTTP on a cache
+// miss.
+func (h *httpRangeReader) chunk(idx int64) ([]byte, error) {
+	h.mu.Lock()
+	if el, ok := h.chunks[idx]; ok {
+		h.lru.MoveToFront(el)
+		data := el.Value.(*rangeChunk).data
+		h.mu.Unlock()
+		return data, nil
+	}
+	h.mu.Unlock()
+
+	start := idx * httpRangeChunkSize
+	end := start + httpRangeChunkSize - 1
+	if end > h.size-1 {
+		end = h.size - 1
+	}
+	req, err := http.NewRequest(http.MethodGet, h.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusPartialContent {
+		// A 200 OK here means the server (or an intermediate proxy)
+		// ignored our Range header and is about to hand back the entire
+		// file; silently treating that as the requested chunk would make
+		// ReadAt index into it as if it were bytes [start, end] and
+		// return wrong audio data instead of erroring.
+		return nil, fmt.Errorf("flac.httpRangeReader.chunk: server did not honor Range request for %q (got status %q, want %d Partial Content)", h.url, resp.Status, http.StatusPartialContent)
+	}
+	// end is already truncated to h.size-1 above, matching how an
+	// RFC-7233-compliant server truncates the final range of a resource
+	// whose length isn't a multiple of httpRangeChunkSize (e.g. Go's own
+	// http.ServeContent), so this doesn't reject an ordinary last chunk.
+	wantPrefix := fmt.Sprintf("bytes %d-%d", start, end)
+	if cr := resp.Header.Get("Content-Range"); !strings.HasPrefix(cr, wantPrefix) {
+		return nil, fmt.Errorf("flac.httpRangeReader.chunk: unexpected Content-Range %q fetching %q, want prefix %q", cr, h.url, wantPrefix)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	h.mu.Lock()
+	el := h.lru.PushFront(&rangeChunk{index: idx, data: data})
+	h.chunks[idx] = el
+	for h.lru.Len() > httpRangeCacheChunks {
+		oldest := h.lru.Back()
+		h.lru.Remove(oldest)
+		delete(h.chunks, oldest.Value.(*rangeChunk).index)
+	}
+	h.mu.Unlock()
+
+	return data, nil
+}
+
+// NewSeekHTTP returns a seek-enabled Stream that lazily fetches the remote
+// FLAC file at url using HTTP Range requests, caching recently accessed
+// byte ranges in an LRU so that repeated seeks within the same region of
+// the file do not re-fetch it. client may be nil, in which case
+// http.DefaultClient is used.
+//
+// The returned stream uses SeekModeLazy (see NewSeekAt), so opening it
+// never triggers a full prescan of the remote file; call
+// SetSeekMode(SeekModeEager) if you want one anyway.
+func NewSeekHTTP(url string, client *http.Client) (stream *Stream, err error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Head(url)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	if resp.ContentLength < 0 {
+		return nil, fmt.Errorf("flac.NewSeekHTTP: server did not report Content-Length for %q", url)
+	}
+	if resp.Header.Get("Accept-Ranges") == "none" {
+		return nil, fmt.Errorf("flac.NewSeekHTTP: server does not support range requests for %q", url)
+	}
+
+	r := newHTTPRangeReader(url, client, resp.ContentLength)
+	return NewSeekAt(r, resp.ContentLength)
+}
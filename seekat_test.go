@@ -0,0 +1,103 @@
+package flac
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestHTTPRangeReaderChunkRejectsIgnoredRange checks that a 200 OK response
+// (a server or proxy ignoring our Range header) is rejected, rather than
+// being accepted as if it were the requested chunk and letting ReadAt index
+// into the full file body as though it were bytes [start, end].
+func TestHTTPRangeReaderChunkRejectsIgnoredRange(t *testing.T) {
+	body := make([]byte, 4*httpRangeChunkSize)
+	for i := range body {
+		body[i] = byte(i)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Ignore the Range header entirely, as a misbehaving server would.
+		w.WriteHeader(http.StatusOK)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	h := newHTTPRangeReader(srv.URL, srv.Client(), int64(len(body)))
+	if _, err := h.chunk(0); err == nil {
+		t.Fatal("chunk: expected error when server ignores Range and returns 200 OK, got nil")
+	}
+}
+
+// TestHTTPRangeReaderChunkRejectsMismatchedContentRange checks that a 206
+// response whose Content-Range does not actually cover the requested chunk
+// is rejected instead of silently accepted.
+func TestHTTPRangeReaderChunkRejectsMismatchedContentRange(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Range", "bytes 99999-100010/100011")
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(make([]byte, httpRangeChunkSize))
+	}))
+	defer srv.Close()
+
+	h := newHTTPRangeReader(srv.URL, srv.Client(), httpRangeChunkSize)
+	if _, err := h.chunk(0); err == nil {
+		t.Fatal("chunk: expected error when Content-Range does not match the requested range, got nil")
+	}
+}
+
+// TestHTTPRangeReaderChunkAcceptsValidRange confirms a well-behaved server's
+// response for a full-size chunk is still accepted.
+func TestHTTPRangeReaderChunkAcceptsValidRange(t *testing.T) {
+	body := make([]byte, httpRangeChunkSize)
+	for i := range body {
+		body[i] = byte(i)
+	}
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		end := httpRangeChunkSize - 1
+		w.Header().Set("Content-Range", "bytes 0-"+strconv.Itoa(end)+"/"+strconv.Itoa(len(body)))
+		w.WriteHeader(http.StatusPartialContent)
+		w.Write(body)
+	}))
+	defer srv.Close()
+
+	h := newHTTPRangeReader(srv.URL, srv.Client(), int64(len(body)))
+	data, err := h.chunk(0)
+	if err != nil {
+		t.Fatalf("chunk: unexpected error: %v", err)
+	}
+	if len(data) != len(body) {
+		t.Fatalf("chunk: got %d bytes, want %d", len(data), len(body))
+	}
+}
+
+// TestHTTPRangeReaderChunkAcceptsTruncatedFinalRange confirms the last
+// chunk of a resource whose length isn't a multiple of httpRangeChunkSize is
+// accepted when the server truncates the final range to the resource's
+// actual length, as any RFC-7233-compliant server (including Go's own
+// http.ServeContent) does. Previously the fixed-size wantPrefix rejected
+// this, breaking ordinary decode-to-EOF on almost any real file.
+func TestHTTPRangeReaderChunkAcceptsTruncatedFinalRange(t *testing.T) {
+	const size = 3*httpRangeChunkSize + 1234
+	body := bytes.Repeat([]byte{0xAB}, size)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.ServeContent(w, r, "stream.flac", time.Time{}, bytes.NewReader(body))
+	}))
+	defer srv.Close()
+
+	h := newHTTPRangeReader(srv.URL, srv.Client(), int64(size))
+	lastIdx := int64((size - 1) / httpRangeChunkSize)
+	data, err := h.chunk(lastIdx)
+	if err != nil {
+		t.Fatalf("chunk: unexpected error on truncated final range: %v", err)
+	}
+	wantLen := size - int(lastIdx)*httpRangeChunkSize
+	if len(data) != wantLen {
+		t.Fatalf("chunk: got %d bytes, want %d", len(data), wantLen)
+	}
+}